@@ -10,25 +10,60 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
-// LSPClient manages communication with gopls
+// Position is an LSP position (0-indexed line/character).
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is an LSP range.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is an LSP location: a URI plus a range within it.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// openFile tracks the version and content lspit last told the server about
+// for a given file, so repeat queries can reuse didOpen instead of
+// resending it and skipped-if-unchanged didChange can be sent otherwise.
+type openFile struct {
+	version int
+	content string
+}
+
+// LSPClient manages communication with a language server
 type LSPClient struct {
-	cmd           *exec.Cmd
-	stdin         io.WriteCloser
-	stdout        io.ReadCloser
-	stderr        io.ReadCloser
-	workspaceRoot string
-	nextID        int
-	mu            sync.Mutex
-	responses     map[int]chan json.RawMessage
-	done          chan struct{}
-}
-
-// NewLSPClient creates and starts a new LSP client
-func NewLSPClient(workspaceRoot string) (*LSPClient, error) {
-	cmd := exec.Command("gopls")
-	
+	cmd             *exec.Cmd
+	stdin           io.WriteCloser
+	stdout          io.ReadCloser
+	stderr          io.ReadCloser
+	workspaceRoot   string
+	config          ServerConfig
+	nextID          int
+	mu              sync.Mutex
+	responses       map[int]chan json.RawMessage
+	done            chan struct{}
+	openFilesMu     sync.Mutex
+	openFiles       map[string]*openFile
+	recorder        io.Writer
+	notifyFunc      func(method string, params json.RawMessage)
+	diagnostics     map[string][]Diagnostic
+	applyEditDryRun bool
+}
+
+// NewLSPClient creates and starts a new LSP client for the given server
+// configuration.
+func NewLSPClient(workspaceRoot string, config ServerConfig) (*LSPClient, error) {
+	cmd := exec.Command(config.Command, config.Args...)
+
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get stdin pipe: %w", err)
@@ -45,7 +80,7 @@ func NewLSPClient(workspaceRoot string) (*LSPClient, error) {
 	}
 
 	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start gopls: %w", err)
+		return nil, fmt.Errorf("failed to start %s: %w", config.Command, err)
 	}
 
 	client := &LSPClient{
@@ -54,9 +89,12 @@ func NewLSPClient(workspaceRoot string) (*LSPClient, error) {
 		stdout:        stdout,
 		stderr:        stderr,
 		workspaceRoot: workspaceRoot,
+		config:        config,
 		nextID:        1,
 		responses:     make(map[int]chan json.RawMessage),
 		done:          make(chan struct{}),
+		openFiles:     make(map[string]*openFile),
+		diagnostics:   make(map[string][]Diagnostic),
 	}
 
 	// Start reading responses in background
@@ -107,11 +145,29 @@ func (c *LSPClient) Initialize() error {
 						"linkSupport": true,
 					},
 					"references": map[string]interface{}{},
+					"codeAction": map[string]interface{}{
+						"codeActionLiteralSupport": map[string]interface{}{
+							"codeActionKind": map[string]interface{}{
+								"valueSet": []string{"quickfix", "refactor", "refactor.rewrite", "source"},
+							},
+						},
+					},
+					"rename": map[string]interface{}{
+						"prepareSupport": true,
+					},
+					"publishDiagnostics": map[string]interface{}{},
+					"formatting":         map[string]interface{}{},
+				},
+				"workspace": map[string]interface{}{
+					"applyEdit": true,
 				},
 			},
 		},
 	}
-	
+	if len(c.config.InitOptions) > 0 {
+		initReq["params"].(map[string]interface{})["initializationOptions"] = c.config.InitOptions
+	}
+
 	id := initReq["id"].(int)
 	respChan := c.registerResponse(id)
 	
@@ -132,31 +188,105 @@ func (c *LSPClient) Initialize() error {
 	return c.sendNotification(initializedNotif)
 }
 
-// Hover gets type information at the specified position
-func (c *LSPClient) Hover(filePath string, line, col int) error {
-	// Open the file and send didOpen notification
+// ensureOpen makes sure the server has an up-to-date view of filePath,
+// sending textDocument/didOpen the first time it sees the file and
+// textDocument/didChange (as a full-text replacement) on later calls if the
+// on-disk content has changed since. This lets a long-lived client (e.g. the
+// daemon) re-issue queries against an edited file without reopening it.
+//
+// The read-decide-send-record sequence below runs under its own
+// openFilesMu, held for the whole call rather than just the map accesses:
+// now that the daemon can service several connections concurrently, two
+// requests racing on the same file must not both see it as unopened (which
+// would send two didOpens) or both compute the same didChange version
+// (which the server would reject as stale). LSP also requires
+// didOpen/didChange for one document to arrive in order, so serializing
+// them here is correct, not just convenient.
+//
+// openFilesMu is deliberately separate from c.mu: sendNotification writes
+// to the wire via sendMessage, which takes c.mu itself (e.g. to consult
+// the recorder), and c.mu is not reentrant. Holding c.mu here instead would
+// self-deadlock on the very first call.
+func (c *LSPClient) ensureOpen(filePath string) error {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
-	
-	didOpenNotif := map[string]interface{}{
+
+	c.openFilesMu.Lock()
+	defer c.openFilesMu.Unlock()
+
+	existing, seen := c.openFiles[filePath]
+
+	if !seen {
+		didOpenNotif := map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  "textDocument/didOpen",
+			"params": map[string]interface{}{
+				"textDocument": map[string]interface{}{
+					"uri":        "file://" + filePath,
+					"languageId": c.config.LanguageID,
+					"version":    1,
+					"text":       string(content),
+				},
+			},
+		}
+		if err := c.sendNotification(didOpenNotif); err != nil {
+			return err
+		}
+		c.openFiles[filePath] = &openFile{version: 1, content: string(content)}
+		return nil
+	}
+
+	if existing.content == string(content) {
+		return nil
+	}
+
+	newVersion := existing.version + 1
+	didChangeNotif := map[string]interface{}{
 		"jsonrpc": "2.0",
-		"method":  "textDocument/didOpen",
+		"method":  "textDocument/didChange",
 		"params": map[string]interface{}{
 			"textDocument": map[string]interface{}{
-				"uri":        "file://" + filePath,
-				"languageId": "go",
-				"version":    1,
-				"text":       string(content),
+				"uri":     "file://" + filePath,
+				"version": newVersion,
+			},
+			"contentChanges": []map[string]interface{}{
+				{"text": string(content)},
 			},
 		},
 	}
-	
-	if err := c.sendNotification(didOpenNotif); err != nil {
+	if err := c.sendNotification(didChangeNotif); err != nil {
 		return err
 	}
-	
+
+	existing.version = newVersion
+	existing.content = string(content)
+	return nil
+}
+
+// forgetFile drops filePath from the open-file cache so the next query
+// reopens it from scratch. Used when a workspace edit is applied directly.
+func (c *LSPClient) forgetFile(filePath string) {
+	c.openFilesMu.Lock()
+	delete(c.openFiles, filePath)
+	c.openFilesMu.Unlock()
+}
+
+// HoverResult bundles hover contents with the range they apply to, so a
+// daemon response can carry both over the wire as a single JSON value.
+type HoverResult struct {
+	Contents interface{} `json:"contents"`
+	Range    *Range      `json:"range,omitempty"`
+}
+
+// Hover gets type information at the specified position, along with the
+// range it applies to (nil if the server didn't report one).
+func (c *LSPClient) Hover(filePath string, line, col int) (interface{}, *Range, error) {
+	if err := c.ensureOpen(filePath); err != nil {
+		return nil, nil, err
+	}
+
 	// Send hover request
 	hoverReq := map[string]interface{}{
 		"jsonrpc": "2.0",
@@ -175,50 +305,33 @@ func (c *LSPClient) Hover(filePath string, line, col int) error {
 	
 	id := hoverReq["id"].(int)
 	respChan := c.registerResponse(id)
-	
+
 	if err := c.sendRequest(hoverReq); err != nil {
-		return fmt.Errorf("failed to send hover request: %w", err)
+		return nil, nil, fmt.Errorf("failed to send hover request: %w", err)
 	}
 
 	// Wait for response
 	resp := <-respChan
-	
-	// Parse and display hover result
+
+	// Parse the hover result
 	var result struct {
 		Contents interface{} `json:"contents"`
-		Range    interface{} `json:"range"`
+		Range    *Range      `json:"range"`
 	}
-	
+
 	if err := json.Unmarshal(resp, &result); err != nil {
-		return fmt.Errorf("failed to parse hover response: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse hover response: %w", err)
 	}
-	
-	// Display the hover information
-	return c.displayHoverInfo(result.Contents)
+
+	return result.Contents, result.Range, nil
 }
 
 // Definition finds the definition of the symbol at the specified position
-func (c *LSPClient) Definition(filePath string, line, col int) error {
-	// Open the file
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
-	}
-	
-	didOpenNotif := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"method":  "textDocument/didOpen",
-		"params": map[string]interface{}{
-			"textDocument": map[string]interface{}{
-				"uri":        "file://" + filePath,
-				"languageId": "go",
-				"version":    1,
-				"text":       string(content),
-			},
-		},
+func (c *LSPClient) Definition(filePath string, line, col int) ([]Location, error) {
+	if err := c.ensureOpen(filePath); err != nil {
+		return nil, err
 	}
-	c.sendNotification(didOpenNotif)
-	
+
 	// Send definition request
 	defReq := map[string]interface{}{
 		"jsonrpc": "2.0",
@@ -239,50 +352,25 @@ func (c *LSPClient) Definition(filePath string, line, col int) error {
 	respChan := c.registerResponse(id)
 
 	if err := c.sendRequest(defReq); err != nil {
-		return fmt.Errorf("failed to send definition request: %w", err)
+		return nil, fmt.Errorf("failed to send definition request: %w", err)
 	}
-	
+
 	resp := <-respChan
-	
-	// Parse and display definition result
-	var locations []struct {
-		URI   string `json:"uri"`
-		Range struct {
-			Start struct {
-				Line      int `json:"line"`
-				Character int `json:"character"`
-			} `json:"start"`
-		} `json:"range"`
-	}
-	
+
+	var locations []Location
 	if err := json.Unmarshal(resp, &locations); err != nil {
-		return fmt.Errorf("failed to parse definition response: %w", err)
+		return nil, fmt.Errorf("failed to parse definition response: %w", err)
 	}
-	
-	return c.displayLocations(locations)
+
+	return locations, nil
 }
 
 // References finds all references to the symbol at the specified position
-func (c *LSPClient) References(filePath string, line, col int) error {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+func (c *LSPClient) References(filePath string, line, col int) ([]Location, error) {
+	if err := c.ensureOpen(filePath); err != nil {
+		return nil, err
 	}
 
-	didOpenNotif := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"method":  "textDocument/didOpen",
-		"params": map[string]interface{}{
-			"textDocument": map[string]interface{}{
-				"uri":        "file://" + filePath,
-				"languageId": "go",
-				"version":    1,
-				"text":       string(content),
-			},
-		},
-	}
-	c.sendNotification(didOpenNotif)
-	
 	// Send references request
 	refsReq := map[string]interface{}{
 		"jsonrpc": "2.0",
@@ -304,29 +392,271 @@ func (c *LSPClient) References(filePath string, line, col int) error {
 	
 	id := refsReq["id"].(int)
 	respChan := c.registerResponse(id)
-	
+
 	if err := c.sendRequest(refsReq); err != nil {
-		return fmt.Errorf("failed to send references request: %w", err)
+		return nil, fmt.Errorf("failed to send references request: %w", err)
 	}
-	
+
 	resp := <-respChan
-	
-	// Parse and display references result
-	var locations []struct {
-		URI   string `json:"uri"`
-		Range struct {
-			Start struct {
-				Line      int `json:"line"`
-				Character int `json:"character"`
-			} `json:"start"`
-		} `json:"range"`
-	}
-	
+
+	var locations []Location
 	if err := json.Unmarshal(resp, &locations); err != nil {
-		return fmt.Errorf("failed to parse references response: %w", err)
+		return nil, fmt.Errorf("failed to parse references response: %w", err)
 	}
-	
-	return c.displayLocations(locations)
+
+	return locations, nil
+}
+
+// CodeActions requests the code actions (quick fixes and refactors)
+// available at the specified position.
+func (c *LSPClient) CodeActions(filePath string, line, col int) ([]CodeAction, error) {
+	if err := c.ensureOpen(filePath); err != nil {
+		return nil, err
+	}
+
+	pos := map[string]interface{}{"line": line - 1, "character": col - 1}
+	req := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.getNextID(),
+		"method":  "textDocument/codeAction",
+		"params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": "file://" + filePath},
+			"range":        map[string]interface{}{"start": pos, "end": pos},
+			"context":      map[string]interface{}{"diagnostics": []interface{}{}},
+		},
+	}
+
+	id := req["id"].(int)
+	respChan := c.registerResponse(id)
+	if err := c.sendRequest(req); err != nil {
+		return nil, fmt.Errorf("failed to send codeAction request: %w", err)
+	}
+	resp := <-respChan
+
+	var actions []CodeAction
+	if err := json.Unmarshal(resp, &actions); err != nil {
+		return nil, fmt.Errorf("failed to parse codeAction response: %w", err)
+	}
+	return actions, nil
+}
+
+// ApplyCodeAction finds the first code action at (line, col) whose kind
+// and title match (either may be left empty to not filter on it) and
+// applies its edit. Used by `lspit fix` to surface gopls analyzer-driven
+// quick fixes like fill_struct and fill_returns as first-class commands.
+func (c *LSPClient) ApplyCodeAction(filePath string, line, col int, kind, titleContains string, dryRun bool) error {
+	actions, err := c.CodeActions(filePath, line, col)
+	if err != nil {
+		return err
+	}
+
+	for _, action := range actions {
+		if kind != "" && action.Kind != kind {
+			continue
+		}
+		if titleContains != "" && !strings.Contains(strings.ToLower(action.Title), strings.ToLower(titleContains)) {
+			continue
+		}
+		if action.Edit == nil {
+			return fmt.Errorf("code action %q did not include an edit (codeAction/resolve is not yet supported)", action.Title)
+		}
+		if err := ApplyWorkspaceEdit(*action.Edit, dryRun); err != nil {
+			return err
+		}
+		c.forgetEditedFiles(*action.Edit)
+		return nil
+	}
+
+	return fmt.Errorf("no matching code action found at %s:%d:%d", filePath, line, col)
+}
+
+// Rename requests a workspace-wide rename of the symbol at the specified
+// position to newName and applies the edits it returns.
+func (c *LSPClient) Rename(filePath string, line, col int, newName string, dryRun bool) error {
+	if err := c.ensureOpen(filePath); err != nil {
+		return err
+	}
+
+	req := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.getNextID(),
+		"method":  "textDocument/rename",
+		"params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": "file://" + filePath},
+			"position":     map[string]interface{}{"line": line - 1, "character": col - 1},
+			"newName":      newName,
+		},
+	}
+
+	id := req["id"].(int)
+	respChan := c.registerResponse(id)
+	if err := c.sendRequest(req); err != nil {
+		return fmt.Errorf("failed to send rename request: %w", err)
+	}
+	resp := <-respChan
+
+	var edit WorkspaceEdit
+	if err := json.Unmarshal(resp, &edit); err != nil {
+		return fmt.Errorf("failed to parse rename response: %w", err)
+	}
+
+	if err := ApplyWorkspaceEdit(edit, dryRun); err != nil {
+		return err
+	}
+	c.forgetEditedFiles(edit)
+	return nil
+}
+
+// Format requests the server's formatting edits for the whole file and
+// applies them.
+func (c *LSPClient) Format(filePath string, dryRun bool) error {
+	if err := c.ensureOpen(filePath); err != nil {
+		return err
+	}
+
+	req := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.getNextID(),
+		"method":  "textDocument/formatting",
+		"params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": "file://" + filePath},
+			"options":      map[string]interface{}{"tabSize": 4, "insertSpaces": false},
+		},
+	}
+
+	id := req["id"].(int)
+	respChan := c.registerResponse(id)
+	if err := c.sendRequest(req); err != nil {
+		return fmt.Errorf("failed to send formatting request: %w", err)
+	}
+	resp := <-respChan
+
+	var edits []TextEdit
+	if err := json.Unmarshal(resp, &edits); err != nil {
+		return fmt.Errorf("failed to parse formatting response: %w", err)
+	}
+	if len(edits) == 0 {
+		fmt.Println("No formatting changes")
+		return nil
+	}
+
+	edit := WorkspaceEdit{Changes: map[string][]TextEdit{"file://" + filePath: edits}}
+	if err := ApplyWorkspaceEdit(edit, dryRun); err != nil {
+		return err
+	}
+	c.forgetEditedFiles(edit)
+	return nil
+}
+
+// Diagnostics returns the most recently published diagnostics for
+// filePath, opening it first if needed and giving the server a brief
+// window to publish before returning whatever has arrived.
+func (c *LSPClient) Diagnostics(filePath string) ([]Diagnostic, error) {
+	if err := c.ensureOpen(filePath); err != nil {
+		return nil, err
+	}
+
+	uri := "file://" + filePath
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.mu.Lock()
+		diags, ok := c.diagnostics[uri]
+		c.mu.Unlock()
+		if ok || time.Now().After(deadline) {
+			return diags, nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// forgetEditedFiles drops every file touched by edit from the open-file
+// cache so the next query reopens it and sees the change just applied.
+func (c *LSPClient) forgetEditedFiles(edit WorkspaceEdit) {
+	for uri := range edit.Changes {
+		c.forgetFile(strings.TrimPrefix(uri, "file://"))
+	}
+	for _, raw := range edit.DocumentChanges {
+		var tde textDocumentEdit
+		if err := json.Unmarshal(raw, &tde); err == nil && tde.TextDocument.URI != "" {
+			c.forgetFile(strings.TrimPrefix(tde.TextDocument.URI, "file://"))
+		}
+	}
+}
+
+// SetApplyEditDryRun controls whether a server-initiated workspace/applyEdit
+// request (e.g. from a code action with deferred resolution) writes to disk
+// or prints a diff instead.
+func (c *LSPClient) SetApplyEditDryRun(dryRun bool) {
+	c.mu.Lock()
+	c.applyEditDryRun = dryRun
+	c.mu.Unlock()
+}
+
+func (c *LSPClient) getApplyEditDryRun() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.applyEditDryRun
+}
+
+// SetRecorder tees every JSON-RPC frame sent and received from now on
+// through w, in the format consumed by `lspit replay`.
+func (c *LSPClient) SetRecorder(w io.Writer) {
+	c.mu.Lock()
+	c.recorder = w
+	c.mu.Unlock()
+}
+
+func (c *LSPClient) getRecorder() io.Writer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.recorder
+}
+
+// SetNotifyHandler registers f to be called with the method and params of
+// every server-to-client notification the read loop sees.
+func (c *LSPClient) SetNotifyHandler(f func(method string, params json.RawMessage)) {
+	c.mu.Lock()
+	c.notifyFunc = f
+	c.mu.Unlock()
+}
+
+func (c *LSPClient) getNotifyHandler() func(string, json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.notifyFunc
+}
+
+// sendRawRequest resends a previously-recorded request under newID and
+// waits for its response. Used by `lspit replay` to re-drive a session
+// without reusing the original (now stale) request IDs.
+func (c *LSPClient) sendRawRequest(content json.RawMessage, newID int) (json.RawMessage, error) {
+	var req map[string]interface{}
+	if err := json.Unmarshal(content, &req); err != nil {
+		return nil, fmt.Errorf("decoding recorded request: %w", err)
+	}
+	req["id"] = newID
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respChan := c.registerResponse(newID)
+	if err := c.sendMessage(data); err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-respChan:
+		return resp, nil
+	case <-time.After(30 * time.Second):
+		return nil, fmt.Errorf("timed out waiting for response to %v", req["method"])
+	}
+}
+
+// sendRawNotification resends a previously-recorded notification verbatim.
+func (c *LSPClient) sendRawNotification(content json.RawMessage) error {
+	return c.sendMessage(content)
 }
 
 // Helper methods for protocol communication
@@ -364,6 +694,12 @@ func (c *LSPClient) sendNotification(notif map[string]interface{}) error {
 }
 
 func (c *LSPClient) sendMessage(data []byte) error {
+	if w := c.getRecorder(); w != nil {
+		if err := writeRecordEntry(w, directionToServer, data); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record outgoing frame: %v\n", err)
+		}
+	}
+
 	msg := fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(data), data)
 	_, err := c.stdin.Write([]byte(msg))
 	return err
@@ -405,86 +741,104 @@ func (c *LSPClient) readLoop() {
 		if _, err := io.ReadFull(reader, content); err != nil {
 			return
 		}
-		
+
+		if w := c.getRecorder(); w != nil {
+			if err := writeRecordEntry(w, directionFromServer, content); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to record incoming frame: %v\n", err)
+			}
+		}
+
 		// Parse message
 		var msg struct {
 			ID     *int            `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
 			Result json.RawMessage `json:"result"`
 		}
-		
+
 		if err := json.Unmarshal(content, &msg); err != nil {
 			continue
 		}
-		
-		// Dispatch response to waiting goroutine
-		if msg.ID != nil {
+
+		switch {
+		case msg.ID != nil && msg.Method == "":
+			// Response to a request we sent.
 			c.mu.Lock()
 			if ch, ok := c.responses[*msg.ID]; ok {
 				ch <- msg.Result
 				delete(c.responses, *msg.ID)
 			}
 			c.mu.Unlock()
+
+		case msg.ID != nil:
+			// Server-to-client request (e.g. workspace/applyEdit), which
+			// expects a response carrying the same id.
+			c.handleServerRequest(*msg.ID, msg.Method, msg.Params)
+
+		case msg.Method != "":
+			c.handleNotification(msg.Method, msg.Params)
 		}
 	}
 }
 
-// Display methods for formatting output
+// handleNotification processes a server-to-client notification. It always
+// updates the client's own state (e.g. the diagnostics cache) and then
+// forwards the notification to any externally registered handler.
+func (c *LSPClient) handleNotification(method string, params json.RawMessage) {
+	if method == "textDocument/publishDiagnostics" {
+		var note struct {
+			URI         string       `json:"uri"`
+			Diagnostics []Diagnostic `json:"diagnostics"`
+		}
+		if err := json.Unmarshal(params, &note); err == nil {
+			c.mu.Lock()
+			c.diagnostics[note.URI] = note.Diagnostics
+			c.mu.Unlock()
+		}
+	}
 
-func (c *LSPClient) displayHoverInfo(contents interface{}) error {
-	if contents == nil {
-		fmt.Println("No hover information available")
-		return nil
+	if handler := c.getNotifyHandler(); handler != nil {
+		handler(method, params)
 	}
-	
-	// Handle different content formats
-	switch v := contents.(type) {
-	case string:
-		fmt.Println(v)
-	case map[string]interface{}:
-		if value, ok := v["value"].(string); ok {
-			// Clean up markdown code blocks
-			value = strings.TrimPrefix(value, "```go\n")
-			value = strings.TrimPrefix(value, "```\n")
-			value = strings.TrimSuffix(value, "\n```")
-			fmt.Println(value)
+}
+
+// handleServerRequest answers a request the server sent us, notably
+// workspace/applyEdit.
+func (c *LSPClient) handleServerRequest(id int, method string, params json.RawMessage) {
+	switch method {
+	case "workspace/applyEdit":
+		var p struct {
+			Edit WorkspaceEdit `json:"edit"`
 		}
-	case []interface{}:
-		for _, item := range v {
-			if str, ok := item.(string); ok {
-				fmt.Println(str)
-			} else if m, ok := item.(map[string]interface{}); ok {
-				if value, ok := m["value"].(string); ok {
-					fmt.Println(value)
-				}
-			}
+		result := map[string]interface{}{"applied": true}
+		if err := json.Unmarshal(params, &p); err != nil {
+			result["applied"] = false
+			result["failureReason"] = err.Error()
+		} else if err := ApplyWorkspaceEdit(p.Edit, c.getApplyEditDryRun()); err != nil {
+			result["applied"] = false
+			result["failureReason"] = err.Error()
+		} else {
+			c.forgetEditedFiles(p.Edit)
 		}
+		c.sendResponse(id, result)
+
+	default:
+		// Unsupported server-to-client request: decline it rather than
+		// leaving the server waiting forever.
+		c.sendResponse(id, nil)
 	}
-	
-	return nil
 }
 
-func (c *LSPClient) displayLocations(locations []struct {
-	URI   string `json:"uri"`
-	Range struct {
-		Start struct {
-			Line      int `json:"line"`
-			Character int `json:"character"`
-		} `json:"start"`
-	} `json:"range"`
-}) error {
-	if len(locations) == 0 {
-		fmt.Println("No locations found")
-		return nil
+func (c *LSPClient) sendResponse(id int, result interface{}) {
+	resp := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"result":  result,
 	}
-	
-	for _, loc := range locations {
-		// Convert file:// URI to path
-		path := strings.TrimPrefix(loc.URI, "file://")
-		// Convert 0-indexed to 1-indexed for display
-		line := loc.Range.Start.Line + 1
-		col := loc.Range.Start.Character + 1
-		fmt.Printf("%s:%d:%d\n", path, line, col)
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
 	}
-	
-	return nil
+	c.sendMessage(data)
 }
+
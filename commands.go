@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// knownFixes maps a lspit "fix" name to the gopls code action kind and
+// title substring it uses, since several analyzer-driven quick fixes can
+// share a kind (e.g. "refactor.rewrite") at the same position.
+var knownFixes = map[string]struct {
+	kind  string
+	title string
+}{
+	"fillstruct":  {kind: "refactor.rewrite", title: "fill struct"},
+	"fillreturns": {kind: "refactor.rewrite", title: "fill in returns"},
+	"typeparams":  {kind: "refactor.rewrite", title: "infer type arguments"},
+}
+
+// resolveFile turns filePath into an absolute path and verifies it exists,
+// exiting the process on failure.
+func resolveFile(filePath string) string {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving file path: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: file not found: %s\n", absPath)
+		os.Exit(1)
+	}
+	return absPath
+}
+
+// extractDryRunFlag pulls a "--dry-run" flag out of args, wherever it
+// appears, returning whether it was present and the remaining arguments.
+func extractDryRunFlag(args []string) (dryRun bool, rest []string) {
+	rest = make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "--dry-run" {
+			dryRun = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return dryRun, rest
+}
+
+// newClientForFile loads the server config and finds the workspace root
+// for absPath, then starts (but does not initialize) a client for it.
+// Exits the process on failure.
+func newClientForFile(absPath string) (client *LSPClient, workspaceRoot string, serverConfig ServerConfig) {
+	serverConfigs, err := loadServerConfigs()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading server config: %v\n", err)
+		os.Exit(1)
+	}
+
+	serverConfig, err = serverConfigForFile(serverConfigs, absPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	workspaceRoot, err = findProjectRoot(absPath, serverConfig.RootMarkers)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding workspace root: %v\n", err)
+		os.Exit(1)
+	}
+
+	client, err = NewLSPClient(workspaceRoot, serverConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating LSP client: %v\n", err)
+		os.Exit(1)
+	}
+
+	return client, workspaceRoot, serverConfig
+}
+
+// startOneShotClient spawns and initializes a language server for absPath,
+// exiting the process on failure. Callers are responsible for closing it.
+func startOneShotClient(absPath string) *LSPClient {
+	client, _, _ := newClientForFile(absPath)
+	if err := client.Initialize(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing LSP server: %v\n", err)
+		os.Exit(1)
+	}
+	return client
+}
+
+func runFixCommand(fixName, absPath string, line, col int, dryRun bool) {
+	fix, ok := knownFixes[fixName]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown fix %q (known: fillstruct, fillreturns, typeparams)\n", fixName)
+		os.Exit(1)
+	}
+
+	client := startOneShotClient(absPath)
+	defer client.Close()
+
+	if err := client.ApplyCodeAction(absPath, line, col, fix.kind, fix.title, dryRun); err != nil {
+		fmt.Fprintf(os.Stderr, "Error applying fix: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runRenameCommand(absPath string, line, col int, newName string, dryRun bool) {
+	client := startOneShotClient(absPath)
+	defer client.Close()
+
+	if err := client.Rename(absPath, line, col, newName, dryRun); err != nil {
+		fmt.Fprintf(os.Stderr, "Error renaming: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runDiagnosticsCommand(absPath string) {
+	client := startOneShotClient(absPath)
+	defer client.Close()
+
+	diagnostics, err := client.Diagnostics(absPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting diagnostics: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(diagnostics) == 0 {
+		fmt.Println("No diagnostics")
+		return
+	}
+	for _, d := range diagnostics {
+		fmt.Printf("%s:%d:%d: %s\n", absPath, d.Range.Start.Line+1, d.Range.Start.Character+1, d.Message)
+	}
+}
+
+func runFormatCommand(absPath string, dryRun bool) {
+	client := startOneShotClient(absPath)
+	defer client.Close()
+
+	if err := client.Format(absPath, dryRun); err != nil {
+		fmt.Fprintf(os.Stderr, "Error formatting: %v\n", err)
+		os.Exit(1)
+	}
+}
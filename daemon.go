@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// daemonIdleTimeout is how long a pooled language server may sit unused
+// before the daemon shuts it down.
+const daemonIdleTimeout = 30 * time.Minute
+
+// daemonRequest is one line of the daemon's JSON line protocol.
+type daemonRequest struct {
+	Cmd  string `json:"cmd"`
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	Col  int    `json:"col,omitempty"`
+}
+
+// daemonResponse is the daemon's reply to a daemonRequest.
+type daemonResponse struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// socketPath returns the unix socket path the daemon listens on and the
+// CLI dials, defaulting to $XDG_RUNTIME_DIR/lspit.sock.
+func socketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "lspit.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("lspit-%d.sock", os.Getuid()))
+}
+
+// runDaemon starts the lspit daemon: it listens on socketPath, pooling one
+// LSPClient per (workspace, language) pair across requests, until it
+// receives a "shutdown" command. Connections are serviced concurrently
+// (one goroutine per conn) so a slow hover/definition/references call on
+// one connection doesn't block others from using the pool.
+func runDaemon() error {
+	path := socketPath()
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to clear stale socket %s: %w", path, err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	defer listener.Close()
+
+	pool := newClientPool()
+	defer pool.closeAll()
+
+	stop := make(chan struct{})
+	go evictIdleLoop(pool, stop)
+	defer close(stop)
+
+	fmt.Printf("lspit daemon listening on %s\n", path)
+
+	shutdown := make(chan struct{})
+	var shutdownOnce sync.Once
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-shutdown:
+				return nil
+			default:
+				return err
+			}
+		}
+
+		go func() {
+			if handleConn(conn, pool) {
+				shutdownOnce.Do(func() {
+					close(shutdown)
+					listener.Close()
+				})
+			}
+		}()
+	}
+}
+
+func evictIdleLoop(pool *clientPool, stop chan struct{}) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			pool.evictIdle(daemonIdleTimeout)
+		}
+	}
+}
+
+// handleConn services requests on one connection until the client
+// disconnects or issues "shutdown", in which case it reports true so the
+// caller can stop the daemon's accept loop.
+func handleConn(conn net.Conn, pool *clientPool) (shutdown bool) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var req daemonRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			writeDaemonResponse(conn, daemonResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+			continue
+		}
+
+		result, err := dispatch(&req, pool)
+		if err != nil {
+			writeDaemonResponse(conn, daemonResponse{Error: err.Error()})
+		} else {
+			data, err := json.Marshal(result)
+			if err != nil {
+				writeDaemonResponse(conn, daemonResponse{Error: err.Error()})
+			} else {
+				writeDaemonResponse(conn, daemonResponse{Result: data})
+			}
+		}
+
+		if req.Cmd == "shutdown" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeDaemonResponse(conn net.Conn, resp daemonResponse) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(daemonResponse{Error: err.Error()})
+	}
+	conn.Write(append(data, '\n'))
+}
+
+// dispatch runs one daemon request against the pool and returns its result.
+func dispatch(req *daemonRequest, pool *clientPool) (interface{}, error) {
+	switch req.Cmd {
+	case "status":
+		return pool.status(), nil
+	case "shutdown":
+		pool.closeAll()
+		return "shutting down", nil
+	}
+
+	if req.File == "" {
+		return nil, fmt.Errorf("request is missing \"file\"")
+	}
+
+	absPath, err := filepath.Abs(req.File)
+	if err != nil {
+		return nil, fmt.Errorf("resolving file path: %w", err)
+	}
+
+	serverConfigs, err := loadServerConfigs()
+	if err != nil {
+		return nil, err
+	}
+	serverConfig, err := serverConfigForFile(serverConfigs, absPath)
+	if err != nil {
+		return nil, err
+	}
+	workspaceRoot, err := findProjectRoot(absPath, serverConfig.RootMarkers)
+	if err != nil {
+		return nil, fmt.Errorf("finding workspace root: %w", err)
+	}
+
+	client, err := pool.get(workspaceRoot, serverConfig)
+	if err != nil {
+		return nil, fmt.Errorf("getting language server: %w", err)
+	}
+
+	switch req.Cmd {
+	case "hover", "type":
+		contents, rng, err := client.Hover(absPath, req.Line, req.Col)
+		if err != nil {
+			return nil, err
+		}
+		return HoverResult{Contents: contents, Range: rng}, nil
+	case "definition", "def":
+		return client.Definition(absPath, req.Line, req.Col)
+	case "references", "refs":
+		return client.References(absPath, req.Line, req.Col)
+	default:
+		return nil, fmt.Errorf("unknown command: %s", req.Cmd)
+	}
+}
+
+// dialDaemon connects to a running daemon, if any. It returns ok=false
+// when no daemon is listening, so callers can fall back to
+// spawning a one-shot server.
+func dialDaemon() (net.Conn, bool) {
+	conn, err := net.DialTimeout("unix", socketPath(), 200*time.Millisecond)
+	if err != nil {
+		return nil, false
+	}
+	return conn, true
+}
+
+// queryDaemon sends one request to a running daemon and returns its raw
+// result, or an error if the daemon itself reported one.
+func queryDaemon(conn net.Conn, req daemonRequest) (json.RawMessage, error) {
+	defer conn.Close()
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("writing to daemon: %w", err)
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("reading from daemon: %w", err)
+		}
+		return nil, fmt.Errorf("daemon closed connection without a response")
+	}
+
+	var resp daemonResponse
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("parsing daemon response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("%s", resp.Error)
+	}
+	return resp.Result, nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+type diffOp struct {
+	kind diffKind
+	line string
+}
+
+// diffContextLines is how many unchanged lines unifiedDiff keeps on either
+// side of a change, matching the default `diff -u` / git context size.
+const diffContextLines = 3
+
+// diffHunk is one @@ ... @@ section of a unified diff: a contiguous run of
+// changed lines plus diffContextLines of surrounding context, along with
+// the line ranges (1-indexed, as in the hunk header) it covers in the old
+// and new file.
+type diffHunk struct {
+	oldStart, oldCount int
+	newStart, newCount int
+	ops                []diffOp
+}
+
+// unifiedDiff renders a real unified diff between oldContent and
+// newContent, labelled with path: standard "@@ -a,b +c,d @@" hunk headers
+// around up to diffContextLines of surrounding context, the same format
+// `patch`/`git apply` expect. Returns "" if the two are identical.
+func unifiedDiff(path, oldContent, newContent string) string {
+	ops := diffLines(splitLines(oldContent), splitLines(newContent))
+	hunks := buildHunks(ops, diffContextLines)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n", path)
+	fmt.Fprintf(&b, "+++ b/%s\n", path)
+	for _, h := range hunks {
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", h.oldStart, h.oldCount, h.newStart, h.newCount)
+		for _, op := range h.ops {
+			switch op.kind {
+			case diffEqual:
+				fmt.Fprintf(&b, " %s\n", op.line)
+			case diffDelete:
+				fmt.Fprintf(&b, "-%s\n", op.line)
+			case diffInsert:
+				fmt.Fprintf(&b, "+%s\n", op.line)
+			}
+		}
+	}
+	return b.String()
+}
+
+// buildHunks groups ops into diffHunks, each covering a run of changed
+// lines padded with up to context lines of surrounding diffEqual context.
+// Changes less than 2*context apart share a single hunk instead of
+// producing two overlapping ones.
+func buildHunks(ops []diffOp, context int) []diffHunk {
+	var changed []int
+	for i, op := range ops {
+		if op.kind != diffEqual {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	// oldAt[i]/newAt[i] is the 1-indexed old/new-file line number that ops[i]
+	// would be at, i.e. one past the count of old/new lines consumed by
+	// ops[:i]. Computed once so a hunk's line-range header can be read off
+	// directly instead of re-walking ops for every hunk.
+	oldAt := make([]int, len(ops)+1)
+	newAt := make([]int, len(ops)+1)
+	oldAt[0], newAt[0] = 1, 1
+	for i, op := range ops {
+		oldAt[i+1], newAt[i+1] = oldAt[i], newAt[i]
+		switch op.kind {
+		case diffEqual:
+			oldAt[i+1]++
+			newAt[i+1]++
+		case diffDelete:
+			oldAt[i+1]++
+		case diffInsert:
+			newAt[i+1]++
+		}
+	}
+
+	type span struct{ start, end int } // ops[start:end], end exclusive
+	var spans []span
+	start, end := changed[0], changed[0]+1
+	for _, idx := range changed[1:] {
+		if idx-end <= 2*context {
+			end = idx + 1
+			continue
+		}
+		spans = append(spans, span{start, end})
+		start, end = idx, idx+1
+	}
+	spans = append(spans, span{start, end})
+
+	hunks := make([]diffHunk, 0, len(spans))
+	for _, sp := range spans {
+		lo, hi := sp.start-context, sp.end+context
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(ops) {
+			hi = len(ops)
+		}
+
+		oldCount := oldAt[hi] - oldAt[lo]
+		newCount := newAt[hi] - newAt[lo]
+		oldStart, newStart := oldAt[lo], newAt[lo]
+		if oldCount == 0 && oldStart > 0 {
+			oldStart--
+		}
+		if newCount == 0 && newStart > 0 {
+			newStart--
+		}
+
+		hunks = append(hunks, diffHunk{
+			oldStart: oldStart,
+			oldCount: oldCount,
+			newStart: newStart,
+			newCount: newCount,
+			ops:      ops[lo:hi],
+		})
+	}
+	return hunks
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes a line-level diff with a classic LCS dynamic program.
+// Adequate for the source-file-sized inputs lspit edits; not meant for
+// huge files.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{diffEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{diffDelete, a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{diffInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{diffDelete, a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{diffInsert, b[j]})
+	}
+	return ops
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiffNoChange(t *testing.T) {
+	content := "a\nb\nc\n"
+	if got := unifiedDiff("f.go", content, content); got != "" {
+		t.Errorf("unifiedDiff(identical content) = %q, want \"\"", got)
+	}
+}
+
+func TestUnifiedDiffOneLineChangeInLargeFile(t *testing.T) {
+	lines := make([]string, 200)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	old := strings.Join(lines, "\n") + "\n"
+	lines[100] = "changed"
+	updated := strings.Join(lines, "\n") + "\n"
+
+	got := unifiedDiff("f.go", old, updated)
+
+	if strings.Count(got, "@@") != 2 {
+		t.Errorf("unifiedDiff should emit exactly one hunk for one isolated change, got:\n%s", got)
+	}
+	if strings.Count(got, "\n") > 20 {
+		t.Errorf("unifiedDiff for a single-line change should stay small (bounded context), got %d lines:\n%s", strings.Count(got, "\n"), got)
+	}
+	if !strings.Contains(got, "-line\n+changed\n") {
+		t.Errorf("unifiedDiff missing the changed line, got:\n%s", got)
+	}
+}
+
+func TestUnifiedDiffMergesNearbyHunks(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\n"
+	updated := "x\nb\nc\nd\ne\nf\ny\n"
+
+	got := unifiedDiff("f.go", old, updated)
+
+	if strings.Count(got, "@@") != 2 {
+		t.Errorf("expected changes within 2*context of each other to merge into one hunk, got:\n%s", got)
+	}
+}
+
+func TestDiffLinesInsertAndDelete(t *testing.T) {
+	ops := diffLines([]string{"a", "b", "c"}, []string{"a", "x", "c"})
+
+	var kinds []diffKind
+	for _, op := range ops {
+		kinds = append(kinds, op.kind)
+	}
+
+	want := []diffKind{diffEqual, diffDelete, diffInsert, diffEqual}
+	if len(kinds) != len(want) {
+		t.Fatalf("diffLines returned %d ops, want %d: %+v", len(kinds), len(want), ops)
+	}
+	for i, k := range want {
+		if kinds[i] != k {
+			t.Errorf("op %d kind = %v, want %v", i, kinds[i], k)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ServerConfig describes how to launch a language server for files of a
+// given extension, and how to recognize the root of its workspace.
+type ServerConfig struct {
+	Command     string                 `json:"command"`
+	Args        []string               `json:"args,omitempty"`
+	LanguageID  string                 `json:"languageId"`
+	RootMarkers []string               `json:"rootMarkers,omitempty"`
+	InitOptions map[string]interface{} `json:"initializationOptions,omitempty"`
+}
+
+// defaultServers are the built-in per-extension server configurations.
+var defaultServers = map[string]ServerConfig{
+	".go":  {Command: "gopls", LanguageID: "go", RootMarkers: []string{"go.mod"}},
+	".rs":  {Command: "rust-analyzer", LanguageID: "rust", RootMarkers: []string{"Cargo.toml"}},
+	".py":  {Command: "pyright-langserver", Args: []string{"--stdio"}, LanguageID: "python", RootMarkers: []string{"pyproject.toml", "setup.py"}},
+	".ts":  {Command: "typescript-language-server", Args: []string{"--stdio"}, LanguageID: "typescript", RootMarkers: []string{"package.json"}},
+	".tsx": {Command: "typescript-language-server", Args: []string{"--stdio"}, LanguageID: "typescriptreact", RootMarkers: []string{"package.json"}},
+	".c":   {Command: "clangd", LanguageID: "c", RootMarkers: []string{"compile_commands.json"}},
+	".cpp": {Command: "clangd", LanguageID: "cpp", RootMarkers: []string{"compile_commands.json"}},
+	".cc":  {Command: "clangd", LanguageID: "cpp", RootMarkers: []string{"compile_commands.json"}},
+	".h":   {Command: "clangd", LanguageID: "c", RootMarkers: []string{"compile_commands.json"}},
+	".hpp": {Command: "clangd", LanguageID: "cpp", RootMarkers: []string{"compile_commands.json"}},
+}
+
+// loadServerConfigs returns the built-in server table merged with any
+// overrides from ~/.config/lspit/config.json. An override is matched by
+// extension (e.g. ".go") and merged field-by-field onto the built-in entry
+// (or onto a zero ServerConfig for a brand-new extension); extensions
+// without an override keep their default untouched. This lets a user
+// override just, say, initializationOptions for .go without having to also
+// repeat gopls's command and root markers.
+func loadServerConfigs() (map[string]ServerConfig, error) {
+	configs := make(map[string]ServerConfig, len(defaultServers))
+	for ext, cfg := range defaultServers {
+		configs[ext] = cfg
+	}
+
+	path, err := userConfigPath()
+	if err != nil {
+		// No resolvable home directory: fall back to built-in defaults.
+		return configs, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return configs, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var overrides map[string]ServerConfig
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	for ext, override := range overrides {
+		configs[ext] = mergeServerConfig(configs[ext], override)
+	}
+	return configs, nil
+}
+
+// mergeServerConfig applies override onto base, keeping base's value for
+// any field override left at its zero value. A config file entry is
+// therefore a set of overrides, not a full replacement: a user who only
+// wants custom initializationOptions for ".go" doesn't also have to repeat
+// gopls's command, args, and root markers.
+func mergeServerConfig(base, override ServerConfig) ServerConfig {
+	merged := base
+	if override.Command != "" {
+		merged.Command = override.Command
+	}
+	if len(override.Args) > 0 {
+		merged.Args = override.Args
+	}
+	if override.LanguageID != "" {
+		merged.LanguageID = override.LanguageID
+	}
+	if len(override.RootMarkers) > 0 {
+		merged.RootMarkers = override.RootMarkers
+	}
+	if len(override.InitOptions) > 0 {
+		merged.InitOptions = override.InitOptions
+	}
+	return merged
+}
+
+func userConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "lspit", "config.json"), nil
+}
+
+// serverConfigForFile picks the server config to use for filePath based on
+// its extension.
+func serverConfigForFile(configs map[string]ServerConfig, filePath string) (ServerConfig, error) {
+	ext := filepath.Ext(filePath)
+	cfg, ok := configs[ext]
+	if !ok {
+		return ServerConfig{}, fmt.Errorf("no language server configured for extension %q", ext)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import "encoding/json"
+
+// Diagnostic is an LSP diagnostic published for an open document via
+// textDocument/publishDiagnostics.
+type Diagnostic struct {
+	Range    Range       `json:"range"`
+	Severity int         `json:"severity,omitempty"`
+	Code     interface{} `json:"code,omitempty"`
+	Source   string      `json:"source,omitempty"`
+	Message  string      `json:"message"`
+}
+
+// CodeAction is a quick fix or refactor offered by the server for a range
+// of a document.
+type CodeAction struct {
+	Title       string          `json:"title"`
+	Kind        string          `json:"kind,omitempty"`
+	Edit        *WorkspaceEdit  `json:"edit,omitempty"`
+	Command     json.RawMessage `json:"command,omitempty"`
+	Diagnostics []Diagnostic    `json:"diagnostics,omitempty"`
+}
+
+// TextEdit replaces the text in Range with NewText.
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// WorkspaceEdit describes file changes returned by the server (from a
+// rename, a code action, or a workspace/applyEdit request) to be applied
+// back to disk.
+type WorkspaceEdit struct {
+	Changes         map[string][]TextEdit `json:"changes,omitempty"`
+	DocumentChanges []json.RawMessage     `json:"documentChanges,omitempty"`
+}
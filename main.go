@@ -1,118 +1,339 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strconv"
 )
 
 func main() {
-	if len(os.Args) < 3 {
+	recordPath, args := extractRecordFlag(os.Args[1:])
+	format, args := extractFormatFlag(args)
+
+	if len(args) < 1 {
 		printUsage()
 		os.Exit(1)
 	}
 
-	command := os.Args[1]
-	filePath := os.Args[2]
+	command := args[0]
 
-	// Convert to absolute path
-	absPath, err := filepath.Abs(filePath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error resolving file path: %v\n", err)
-		os.Exit(1)
+	switch command {
+	case "daemon":
+		if err := runDaemon(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error running daemon: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "status", "shutdown":
+		runAdminCommand(command)
+		return
+	case "replay":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Usage: lspit replay <log> [--against <command>] [--serve]")
+			os.Exit(1)
+		}
+		if extractServeFlag(args[2:]) {
+			if err := runReplayServe(args[1]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error serving replay: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		against := extractAgainstFlag(args[2:])
+		if err := runReplay(args[1], against); err != nil {
+			fmt.Fprintf(os.Stderr, "Error replaying session: %v\n", err)
+			os.Exit(1)
+		}
+		return
+
+	case "fix":
+		dryRun, rest := extractDryRunFlag(args[1:])
+		if len(rest) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: lspit fix <name> <file> <line> <column> [--dry-run]")
+			os.Exit(1)
+		}
+		absPath := resolveFile(rest[1])
+		line, col, err := parsePosition(rest[2], rest[3])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing position: %v\n", err)
+			os.Exit(1)
+		}
+		runFixCommand(rest[0], absPath, line, col, dryRun)
+		return
+
+	case "rename":
+		dryRun, rest := extractDryRunFlag(args[1:])
+		if len(rest) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: lspit rename <file> <line> <column> <new-name> [--dry-run]")
+			os.Exit(1)
+		}
+		absPath := resolveFile(rest[0])
+		line, col, err := parsePosition(rest[1], rest[2])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing position: %v\n", err)
+			os.Exit(1)
+		}
+		runRenameCommand(absPath, line, col, rest[3], dryRun)
+		return
+
+	case "diagnostics", "diag":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Usage: lspit diagnostics <file>")
+			os.Exit(1)
+		}
+		runDiagnosticsCommand(resolveFile(args[1]))
+		return
+
+	case "format":
+		dryRun, rest := extractDryRunFlag(args[1:])
+		if len(rest) < 1 {
+			fmt.Fprintln(os.Stderr, "Usage: lspit format <file> [--dry-run]")
+			os.Exit(1)
+		}
+		runFormatCommand(resolveFile(rest[0]), dryRun)
+		return
 	}
 
-	// Verify file exists
-	if _, err := os.Stat(absPath); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: file not found: %s\n", absPath)
+	if len(args) < 2 {
+		printUsage()
 		os.Exit(1)
 	}
 
-	// Find workspace root (git repository root)
-	workspaceRoot, err := findGitRoot(absPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error finding workspace root: %v\n", err)
+	absPath := resolveFile(args[1])
+
+	switch command {
+	case "hover", "type", "definition", "def", "references", "refs":
+		if len(args) < 4 {
+			fmt.Fprintf(os.Stderr, "Usage: %s %s <file> <line> <column>\n", os.Args[0], command)
+			os.Exit(1)
+		}
+		line, col, err := parsePosition(args[2], args[3])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing position: %v\n", err)
+			os.Exit(1)
+		}
+		runPositionCommand(command, absPath, line, col, recordPath, format)
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
+		printUsage()
 		os.Exit(1)
 	}
+}
 
-	// Create LSP client
-	client, err := NewLSPClient(workspaceRoot)
+// runPositionCommand executes hover/definition/references. It tries a
+// running daemon first, and falls back to spawning a one-shot language
+// server if none is listening. Recording a session (--record) always uses
+// a dedicated one-shot server, so the log captures the full handshake.
+func runPositionCommand(command, absPath string, line, col int, recordPath, format string) {
+	emitter, err := newEmitter(format)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating LSP client: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+
+	if recordPath == "" {
+		if conn, ok := dialDaemon(); ok {
+			result, err := queryDaemon(conn, daemonRequest{Cmd: command, File: absPath, Line: line, Col: col})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error from daemon: %v\n", err)
+				os.Exit(1)
+			}
+			if err := displayResult(command, result, emitter); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	// No daemon running (or recording was requested): spawn a one-shot
+	// language server for this request.
+	client, workspaceRoot, serverConfig := newClientForFile(absPath)
 	defer client.Close()
 
-	// Initialize the LSP server
+	if recordPath != "" {
+		logFile, err := os.Create(recordPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating record log: %v\n", err)
+			os.Exit(1)
+		}
+		defer logFile.Close()
+
+		if err := writeSessionMeta(logFile, workspaceRoot, serverConfig); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing record log: %v\n", err)
+			os.Exit(1)
+		}
+		client.SetRecorder(logFile)
+	}
+
 	if err := client.Initialize(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing LSP server: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Execute command
 	switch command {
 	case "hover", "type":
-		if len(os.Args) < 5 {
-			fmt.Fprintf(os.Stderr, "Usage: %s hover <file> <line> <column>\n", os.Args[0])
-			os.Exit(1)
-		}
-		line, col, err := parsePosition(os.Args[3], os.Args[4])
+		contents, rng, err := client.Hover(absPath, line, col)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing position: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error getting hover info: %v\n", err)
 			os.Exit(1)
 		}
-		if err := client.Hover(absPath, line, col); err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting hover info: %v\n", err)
+		if err := emitter.EmitHover(contents, rng); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
 	case "definition", "def":
-		if len(os.Args) < 5 {
-			fmt.Fprintf(os.Stderr, "Usage: %s definition <file> <line> <column>\n", os.Args[0])
-			os.Exit(1)
-		}
-		line, col, err := parsePosition(os.Args[3], os.Args[4])
+		locations, err := client.Definition(absPath, line, col)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing position: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error getting definition: %v\n", err)
 			os.Exit(1)
 		}
-		if err := client.Definition(absPath, line, col); err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting definition: %v\n", err)
+		if err := emitter.EmitLocations(locations); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
 	case "references", "refs":
-		if len(os.Args) < 5 {
-			fmt.Fprintf(os.Stderr, "Usage: %s references <file> <line> <column>\n", os.Args[0])
-			os.Exit(1)
-		}
-		line, col, err := parsePosition(os.Args[3], os.Args[4])
+		locations, err := client.References(absPath, line, col)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing position: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error getting references: %v\n", err)
 			os.Exit(1)
 		}
-		if err := client.References(absPath, line, col); err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting references: %v\n", err)
+		if err := emitter.EmitLocations(locations); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+	}
+}
+
+// displayResult renders a daemon response for command through emitter, the
+// same as the one-shot path does.
+func displayResult(command string, result json.RawMessage, emitter Emitter) error {
+	switch command {
+	case "hover", "type":
+		var hover HoverResult
+		if err := json.Unmarshal(result, &hover); err != nil {
+			return fmt.Errorf("parsing daemon hover result: %w", err)
+		}
+		return emitter.EmitHover(hover.Contents, hover.Range)
+
+	case "definition", "def", "references", "refs":
+		var locations []Location
+		if err := json.Unmarshal(result, &locations); err != nil {
+			return fmt.Errorf("parsing daemon location result: %w", err)
+		}
+		return emitter.EmitLocations(locations)
 
 	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
-		printUsage()
+		return fmt.Errorf("don't know how to display result for %q", command)
+	}
+}
+
+// runAdminCommand sends a "status" or "shutdown" command to a running
+// daemon and prints its response.
+func runAdminCommand(command string) {
+	conn, ok := dialDaemon()
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Error: no lspit daemon is running")
+		os.Exit(1)
+	}
+
+	result, err := queryDaemon(conn, daemonRequest{Cmd: command})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error from daemon: %v\n", err)
 		os.Exit(1)
 	}
+
+	var pretty interface{}
+	if err := json.Unmarshal(result, &pretty); err == nil {
+		data, _ := json.MarshalIndent(pretty, "", "  ")
+		fmt.Println(string(data))
+	}
+}
+
+// extractRecordFlag pulls "--record <path>" out of args, wherever it
+// appears, returning the recorded path (empty if absent) and the remaining
+// arguments in order.
+func extractRecordFlag(args []string) (recordPath string, rest []string) {
+	rest = make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--record" && i+1 < len(args) {
+			recordPath = args[i+1]
+			i++
+			continue
+		}
+		rest = append(rest, args[i])
+	}
+	return recordPath, rest
+}
+
+// extractFormatFlag pulls "--format <plain|json|ndjson>" out of args,
+// wherever it appears, defaulting to "plain" if absent.
+func extractFormatFlag(args []string) (format string, rest []string) {
+	format = string(FormatPlain)
+	rest = make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--format" && i+1 < len(args) {
+			format = args[i+1]
+			i++
+			continue
+		}
+		rest = append(rest, args[i])
+	}
+	return format, rest
+}
+
+// extractAgainstFlag pulls "--against <command>" out of a replay
+// subcommand's trailing arguments.
+func extractAgainstFlag(args []string) (against string) {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--against" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// extractServeFlag reports whether "--serve" appears in a replay
+// subcommand's trailing arguments.
+func extractServeFlag(args []string) bool {
+	for _, arg := range args {
+		if arg == "--serve" {
+			return true
+		}
+	}
+	return false
 }
 
 func printUsage() {
-	fmt.Println("Usage: mvp-lsp-client <command> <file> [args...]")
+	fmt.Println("Usage: lspit [--record <log>] [--format plain|json|ndjson] <command> <file> [args...]")
 	fmt.Println()
 	fmt.Println("Commands:")
 	fmt.Println("  hover <file> <line> <column>      Get type information at position")
 	fmt.Println("  definition <file> <line> <column> Find definition of symbol")
 	fmt.Println("  references <file> <line> <column> Find all references to symbol")
+	fmt.Println("  daemon                            Run as a background server pool")
+	fmt.Println("  status                            Show the running daemon's pooled servers")
+	fmt.Println("  shutdown                          Stop the running daemon")
+	fmt.Println("  replay <log> [--against <command>] Re-drive a --record log against a server")
+	fmt.Println("  replay <log> --serve               Fake a server, answering a real client from the log")
+	fmt.Println("  fix <name> <file> <line> <column> [--dry-run]    Apply a known quick fix")
+	fmt.Println("  rename <file> <line> <column> <new-name> [--dry-run]  Rename a symbol")
+	fmt.Println("  diagnostics <file>                 Show published diagnostics for a file")
+	fmt.Println("  format <file> [--dry-run]          Apply the server's formatting edits")
 	fmt.Println()
 	fmt.Println("Line and column numbers are 1-indexed (matching editor display)")
+	fmt.Println("hover/definition/references transparently use a running daemon if present.")
+	fmt.Println("--record <log> captures the JSON-RPC session of hover/definition/references")
+	fmt.Println("for later `lspit replay`; other commands ignore it.")
+	fmt.Println("replay re-drives a real server from the log and diffs its responses; with")
+	fmt.Println("--serve it instead acts as the server, feeding a real client the recorded")
+	fmt.Println("responses (point your editor's language server command at that instead).")
+	fmt.Println("--dry-run prints a unified diff instead of writing file changes to disk.")
+	fmt.Println("--format json/ndjson emits machine-readable output for hover/definition/references.")
 }
 
 func parsePosition(lineStr, colStr string) (int, int, error) {
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// OutputFormat is the rendering lspit uses for command results, selected
+// with the global --format flag.
+type OutputFormat string
+
+const (
+	FormatPlain  OutputFormat = "plain"
+	FormatJSON   OutputFormat = "json"
+	FormatNDJSON OutputFormat = "ndjson"
+)
+
+// Emitter renders the result of a position-based command (hover,
+// definition, references) in one of lspit's output formats. Future
+// commands that produce hover-like or location-like results (symbols,
+// diagnostics) can reuse the same interface.
+type Emitter interface {
+	EmitHover(contents interface{}, rng *Range) error
+	EmitLocations(locations []Location) error
+}
+
+// newEmitter builds the Emitter for the requested --format, defaulting to
+// plain text when format is empty.
+func newEmitter(format string) (Emitter, error) {
+	switch OutputFormat(format) {
+	case FormatPlain, "":
+		return plainEmitter{}, nil
+	case FormatJSON:
+		return jsonEmitter{}, nil
+	case FormatNDJSON:
+		return ndjsonEmitter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown --format %q (want plain, json, or ndjson)", format)
+	}
+}
+
+// hoverContentItem is the normalized shape of one piece of hover content,
+// used for both the jsonEmitter and the ndjsonEmitter.
+type hoverContentItem struct {
+	Language string `json:"language,omitempty"`
+	Value    string `json:"value"`
+}
+
+// hoverJSON is the stable JSON schema for a hover result.
+type hoverJSON struct {
+	Kind     string             `json:"kind"`
+	Contents []hoverContentItem `json:"contents"`
+	Range    *Range             `json:"range,omitempty"`
+}
+
+// locationResult is the stable JSON schema for one definition/reference
+// location, with a one-line source preview read from disk.
+type locationResult struct {
+	URI     string `json:"uri"`
+	Path    string `json:"path"`
+	Range   Range  `json:"range"`
+	Preview string `json:"preview"`
+}
+
+// plainEmitter reproduces lspit's original freeform human-readable output.
+type plainEmitter struct{}
+
+func (plainEmitter) EmitHover(contents interface{}, rng *Range) error {
+	if contents == nil {
+		fmt.Println("No hover information available")
+		return nil
+	}
+
+	switch v := contents.(type) {
+	case string:
+		fmt.Println(v)
+	case map[string]interface{}:
+		if value, ok := v["value"].(string); ok {
+			fmt.Println(trimMarkdownCodeFence(value))
+		}
+	case []interface{}:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				fmt.Println(str)
+			} else if m, ok := item.(map[string]interface{}); ok {
+				if value, ok := m["value"].(string); ok {
+					fmt.Println(value)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (plainEmitter) EmitLocations(locations []Location) error {
+	if len(locations) == 0 {
+		fmt.Println("No locations found")
+		return nil
+	}
+
+	for _, loc := range locations {
+		path := strings.TrimPrefix(loc.URI, "file://")
+		line := loc.Range.Start.Line + 1
+		col := loc.Range.Start.Character + 1
+		fmt.Printf("%s:%d:%d\n", path, line, col)
+	}
+
+	return nil
+}
+
+func trimMarkdownCodeFence(value string) string {
+	value = strings.TrimPrefix(value, "```go\n")
+	value = strings.TrimPrefix(value, "```\n")
+	value = strings.TrimSuffix(value, "\n```")
+	return value
+}
+
+// jsonEmitter prints one indented JSON document per command.
+type jsonEmitter struct{}
+
+func (jsonEmitter) EmitHover(contents interface{}, rng *Range) error {
+	return printJSON(hoverJSON{Kind: "hover", Contents: normalizeHoverContents(contents), Range: rng})
+}
+
+func (jsonEmitter) EmitLocations(locations []Location) error {
+	results := make([]locationResult, len(locations))
+	for i, loc := range locations {
+		results[i] = toLocationResult(loc)
+	}
+	return printJSON(results)
+}
+
+// ndjsonEmitter streams one compact JSON object per line, so a location
+// list can be piped into line-oriented tools (e.g. `lspit refs ... --format
+// ndjson | fzf`). The locations are printed once the full server response
+// has arrived.
+type ndjsonEmitter struct{}
+
+func (ndjsonEmitter) EmitHover(contents interface{}, rng *Range) error {
+	return printNDJSON(hoverJSON{Kind: "hover", Contents: normalizeHoverContents(contents), Range: rng})
+}
+
+func (ndjsonEmitter) EmitLocations(locations []Location) error {
+	for _, loc := range locations {
+		if err := printNDJSON(toLocationResult(loc)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func printNDJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func toLocationResult(loc Location) locationResult {
+	path := strings.TrimPrefix(loc.URI, "file://")
+	return locationResult{
+		URI:     loc.URI,
+		Path:    path,
+		Range:   loc.Range,
+		Preview: sourcePreview(path, loc.Range.Start.Line),
+	}
+}
+
+// sourcePreview returns the 0-indexed line from path, or "" if it can't be
+// read (e.g. the file no longer exists at that path).
+func sourcePreview(path string, line int) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(content), "\n")
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	return strings.TrimRight(lines[line], "\r")
+}
+
+// normalizeHoverContents flattens the several shapes an LSP hover's
+// "contents" field can take (a plain string, a MarkedString or
+// MarkupContent object, or an array of any of those) into a stable list.
+func normalizeHoverContents(contents interface{}) []hoverContentItem {
+	switch v := contents.(type) {
+	case string:
+		return []hoverContentItem{{Value: v}}
+	case map[string]interface{}:
+		item := hoverContentItem{}
+		if language, ok := v["language"].(string); ok {
+			item.Language = language
+		}
+		if value, ok := v["value"].(string); ok {
+			item.Value = value
+		}
+		return []hoverContentItem{item}
+	case []interface{}:
+		items := make([]hoverContentItem, 0, len(v))
+		for _, entry := range v {
+			items = append(items, normalizeHoverContents(entry)...)
+		}
+		return items
+	default:
+		return nil
+	}
+}
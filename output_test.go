@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestNormalizeHoverContents(t *testing.T) {
+	cases := []struct {
+		name string
+		in   interface{}
+		want []hoverContentItem
+	}{
+		{
+			name: "plain string",
+			in:   "hello",
+			want: []hoverContentItem{{Value: "hello"}},
+		},
+		{
+			name: "marked string object",
+			in:   map[string]interface{}{"language": "go", "value": "func f()"},
+			want: []hoverContentItem{{Language: "go", Value: "func f()"}},
+		},
+		{
+			name: "markup content object has no language",
+			in:   map[string]interface{}{"kind": "markdown", "value": "**bold**"},
+			want: []hoverContentItem{{Value: "**bold**"}},
+		},
+		{
+			name: "array of mixed shapes",
+			in:   []interface{}{"a", map[string]interface{}{"language": "go", "value": "b"}},
+			want: []hoverContentItem{{Value: "a"}, {Language: "go", Value: "b"}},
+		},
+		{
+			name: "unrecognized shape",
+			in:   42,
+			want: nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := normalizeHoverContents(c.in)
+			if len(got) != len(c.want) {
+				t.Fatalf("normalizeHoverContents(%v) = %+v, want %+v", c.in, got, c.want)
+			}
+			for i := range got {
+				if got[i] != c.want[i] {
+					t.Errorf("item %d = %+v, want %+v", i, got[i], c.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestToLocationResult(t *testing.T) {
+	loc := Location{
+		URI:   "file:///tmp/does-not-exist.go",
+		Range: Range{Start: Position{Line: 4, Character: 1}, End: Position{Line: 4, Character: 5}},
+	}
+
+	got := toLocationResult(loc)
+
+	if got.Path != "/tmp/does-not-exist.go" {
+		t.Errorf("Path = %q, want the URI with its file:// prefix stripped", got.Path)
+	}
+	if got.Preview != "" {
+		t.Errorf("Preview = %q, want empty for a file that doesn't exist", got.Preview)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// poolKey identifies a pooled client by workspace and language, since a
+// single workspace can host files belonging to several language servers.
+type poolKey struct {
+	workspaceRoot string
+	languageID    string
+}
+
+type pooledClient struct {
+	client   *LSPClient
+	lastUsed time.Time
+}
+
+// clientPool keeps one initialized LSPClient per (workspace, language) pair
+// alive across daemon requests, instead of spawning a fresh server per call.
+type clientPool struct {
+	mu      sync.Mutex
+	clients map[poolKey]*pooledClient
+}
+
+func newClientPool() *clientPool {
+	return &clientPool{clients: make(map[poolKey]*pooledClient)}
+}
+
+// get returns the pooled client for (workspaceRoot, config.LanguageID),
+// starting and initializing one if this is the first request for that pair.
+func (p *clientPool) get(workspaceRoot string, config ServerConfig) (*LSPClient, error) {
+	key := poolKey{workspaceRoot: workspaceRoot, languageID: config.LanguageID}
+
+	p.mu.Lock()
+	if entry, ok := p.clients[key]; ok {
+		entry.lastUsed = time.Now()
+		p.mu.Unlock()
+		return entry.client, nil
+	}
+	p.mu.Unlock()
+
+	client, err := NewLSPClient(workspaceRoot, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Initialize(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to initialize %s: %w", config.Command, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if entry, ok := p.clients[key]; ok {
+		// Lost a race with another goroutine that created this same entry
+		// first; keep theirs and shut down the one we just started.
+		client.Close()
+		entry.lastUsed = time.Now()
+		return entry.client, nil
+	}
+	p.clients[key] = &pooledClient{client: client, lastUsed: time.Now()}
+	return client, nil
+}
+
+// evictIdle closes and forgets any client that hasn't been used in timeout.
+func (p *clientPool) evictIdle(timeout time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for key, entry := range p.clients {
+		if now.Sub(entry.lastUsed) >= timeout {
+			entry.client.Close()
+			delete(p.clients, key)
+		}
+	}
+}
+
+// closeAll shuts down every pooled client.
+func (p *clientPool) closeAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, entry := range p.clients {
+		entry.client.Close()
+		delete(p.clients, key)
+	}
+}
+
+// status summarizes the pool for the daemon's "status" admin command.
+func (p *clientPool) status() interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	type serverStatus struct {
+		Workspace string `json:"workspace"`
+		Language  string `json:"language"`
+		IdleSecs  int    `json:"idleSeconds"`
+	}
+
+	servers := make([]serverStatus, 0, len(p.clients))
+	now := time.Now()
+	for key, entry := range p.clients {
+		servers = append(servers, serverStatus{
+			Workspace: key.workspaceRoot,
+			Language:  key.languageID,
+			IdleSecs:  int(now.Sub(entry.lastUsed).Seconds()),
+		})
+	}
+
+	return struct {
+		Servers []serverStatus `json:"servers"`
+	}{Servers: servers}
+}
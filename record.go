@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// recordDirection marks which way a frame traveled in a --record log.
+type recordDirection string
+
+const (
+	directionMeta       recordDirection = "meta"
+	directionToServer   recordDirection = "send"
+	directionFromServer recordDirection = "recv"
+)
+
+// sessionMeta is recorded once at the start of a --record log so `lspit
+// replay` can reconstruct the session without the original CLI invocation.
+type sessionMeta struct {
+	WorkspaceRoot string       `json:"workspaceRoot"`
+	Server        ServerConfig `json:"server"`
+}
+
+// recordEntry is one line of a --record log: a single JSON-RPC frame (or
+// the session metadata header) with a direction marker, timestamp, and
+// content length.
+type recordEntry struct {
+	Direction     recordDirection `json:"dir"`
+	Time          time.Time       `json:"time"`
+	ContentLength int             `json:"contentLength"`
+	Content       json.RawMessage `json:"content"`
+}
+
+// writeRecordEntry appends one line to w.
+func writeRecordEntry(w io.Writer, dir recordDirection, content []byte) error {
+	entry := recordEntry{
+		Direction:     dir,
+		Time:          time.Now(),
+		ContentLength: len(content),
+		Content:       json.RawMessage(content),
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encoding record entry: %w", err)
+	}
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
+
+// writeSessionMeta writes the session metadata header that must appear
+// first in every --record log.
+func writeSessionMeta(w io.Writer, workspaceRoot string, config ServerConfig) error {
+	meta := sessionMeta{WorkspaceRoot: workspaceRoot, Server: config}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("encoding session metadata: %w", err)
+	}
+	return writeRecordEntry(w, directionMeta, data)
+}
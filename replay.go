@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+)
+
+// logFrame is one parsed entry from a --record log, classified into a
+// request, response, or notification by the shape of its JSON-RPC content.
+type logFrame struct {
+	Direction recordDirection
+	Kind      string // "request", "response", or "notification"
+	Method    string
+	ID        *int
+	Params    json.RawMessage
+	Result    json.RawMessage
+}
+
+// parseLogFile reads a --record log, returning the session metadata
+// recorded at its start and the ordered list of frames that followed.
+func parseLogFile(path string) (sessionMeta, []logFrame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return sessionMeta{}, nil, fmt.Errorf("opening log: %w", err)
+	}
+	defer f.Close()
+
+	var meta sessionMeta
+	var frames []logFrame
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry recordEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return sessionMeta{}, nil, fmt.Errorf("parsing log entry: %w", err)
+		}
+
+		if entry.Direction == directionMeta {
+			if err := json.Unmarshal(entry.Content, &meta); err != nil {
+				return sessionMeta{}, nil, fmt.Errorf("parsing session metadata: %w", err)
+			}
+			continue
+		}
+
+		var raw struct {
+			ID     *int            `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(entry.Content, &raw); err != nil {
+			return sessionMeta{}, nil, fmt.Errorf("parsing JSON-RPC frame: %w", err)
+		}
+
+		kind := "notification"
+		switch {
+		case raw.ID != nil && raw.Method != "":
+			kind = "request"
+		case raw.ID != nil:
+			kind = "response"
+		}
+
+		frames = append(frames, logFrame{
+			Direction: entry.Direction,
+			Kind:      kind,
+			Method:    raw.Method,
+			ID:        raw.ID,
+			Params:    raw.Params,
+			Result:    raw.Result,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return sessionMeta{}, nil, fmt.Errorf("reading log: %w", err)
+	}
+
+	return meta, frames, nil
+}
+
+// runReplay re-drives a fresh language server with the client-to-server
+// frames from a recorded session, diffing its responses against the ones
+// that were recorded and reporting any server-to-client notifications that
+// were dropped or newly introduced. against overrides the recorded
+// server's command (e.g. to regression-test a gopls upgrade), keeping
+// everything else about the session the same.
+//
+// This is the "re-drive and diff" half of replay; the inverse, standing in
+// as a fake server for a real client, is runReplayServe (replayserve.go).
+func runReplay(logPath, against string) error {
+	meta, frames, err := parseLogFile(logPath)
+	if err != nil {
+		return err
+	}
+
+	config := meta.Server
+	if against != "" {
+		config.Command = against
+	}
+
+	client, err := NewLSPClient(meta.WorkspaceRoot, config)
+	if err != nil {
+		return fmt.Errorf("starting %s: %w", config.Command, err)
+	}
+	defer client.Close()
+
+	var replayedNotifications []string
+	client.SetNotifyHandler(func(method string, _ json.RawMessage) {
+		replayedNotifications = append(replayedNotifications, method)
+	})
+
+	if err := client.Initialize(); err != nil {
+		return fmt.Errorf("initializing %s: %w", config.Command, err)
+	}
+
+	// recordedResponses indexes response frames by the original request ID
+	// they answered, so a replayed response (which travels under a fresh,
+	// remapped ID) can still be matched back to what was recorded.
+	recordedResponses := make(map[int]logFrame)
+	var recordedNotifications []string
+	for _, frame := range frames {
+		if frame.Direction != directionFromServer {
+			continue
+		}
+		switch frame.Kind {
+		case "response":
+			recordedResponses[*frame.ID] = frame
+		case "notification":
+			recordedNotifications = append(recordedNotifications, frame.Method)
+		}
+	}
+
+	mismatches := 0
+	for _, frame := range frames {
+		if frame.Direction != directionToServer {
+			continue
+		}
+		// initialize/initialized were already sent by the Initialize() call
+		// above; resending them is invalid per the LSP spec and most servers
+		// reject the duplicate, so skip them here.
+		if frame.Method == "initialize" || frame.Method == "initialized" {
+			continue
+		}
+
+		switch frame.Kind {
+		case "notification":
+			if err := client.sendRawNotification(rawFrameJSON(frame)); err != nil {
+				return fmt.Errorf("resending %s: %w", frame.Method, err)
+			}
+
+		case "request":
+			newID := client.getNextID()
+			resp, err := client.sendRawRequest(rawFrameJSON(frame), newID)
+			if err != nil {
+				return fmt.Errorf("replaying %s: %w", frame.Method, err)
+			}
+
+			recorded, ok := recordedResponses[*frame.ID]
+			if !ok {
+				fmt.Printf("[no recorded response] %s (id %d)\n", frame.Method, *frame.ID)
+				continue
+			}
+			if !jsonEqual(recorded.Result, resp) {
+				mismatches++
+				fmt.Printf("[MISMATCH] %s (id %d)\n  recorded: %s\n  replayed: %s\n", frame.Method, *frame.ID, recorded.Result, resp)
+			}
+		}
+	}
+
+	reportNotificationDiff(recordedNotifications, replayedNotifications)
+
+	if mismatches > 0 {
+		return fmt.Errorf("%d response(s) differed from the recording", mismatches)
+	}
+	fmt.Println("Replay matched the recorded session.")
+	return nil
+}
+
+// rawFrameJSON reconstructs the bytes lspit would have sent for a recorded
+// client-to-server frame, from the method/params/id captured while parsing
+// the log. The id is a placeholder for requests; sendRawRequest overwrites
+// it with a freshly allocated one before sending.
+func rawFrameJSON(frame logFrame) json.RawMessage {
+	obj := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  frame.Method,
+	}
+	if frame.Params != nil {
+		obj["params"] = frame.Params
+	}
+	if frame.ID != nil {
+		obj["id"] = *frame.ID
+	}
+	data, _ := json.Marshal(obj)
+	return data
+}
+
+// jsonEqual compares two JSON-RPC result payloads for semantic equality,
+// ignoring key order.
+func jsonEqual(a, b json.RawMessage) bool {
+	var av, bv interface{}
+	if err := json.Unmarshal(a, &av); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(b, &bv); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+func reportNotificationDiff(recorded, replayed []string) {
+	recordedCounts := countMethods(recorded)
+	replayedCounts := countMethods(replayed)
+
+	for method, count := range recordedCounts {
+		if replayedCounts[method] < count {
+			fmt.Printf("[dropped notification] %s: recorded %d, replayed %d\n", method, count, replayedCounts[method])
+		}
+	}
+	for method, count := range replayedCounts {
+		if recordedCounts[method] < count {
+			fmt.Printf("[extra notification] %s: recorded %d, replayed %d\n", method, recordedCounts[method], count)
+		}
+	}
+}
+
+func countMethods(methods []string) map[string]int {
+	counts := make(map[string]int)
+	for _, m := range methods {
+		counts[m]++
+	}
+	return counts
+}
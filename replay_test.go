@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestJSONEqual(t *testing.T) {
+	cases := []struct {
+		name     string
+		a, b     string
+		wantSame bool
+	}{
+		{"identical", `{"a":1,"b":2}`, `{"a":1,"b":2}`, true},
+		{"key order doesn't matter", `{"a":1,"b":2}`, `{"b":2,"a":1}`, true},
+		{"different value", `{"a":1}`, `{"a":2}`, false},
+		{"different shape", `{"a":1}`, `[1]`, false},
+		{"invalid json", `{`, `{}`, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := jsonEqual([]byte(c.a), []byte(c.b))
+			if got != c.wantSame {
+				t.Errorf("jsonEqual(%s, %s) = %v, want %v", c.a, c.b, got, c.wantSame)
+			}
+		})
+	}
+}
+
+func TestBuildReplaySteps(t *testing.T) {
+	id1, id2 := 1, 2
+	frames := []logFrame{
+		{Direction: directionToServer, Kind: "request", Method: "initialize", ID: &id1},
+		{Direction: directionFromServer, Kind: "response", ID: &id1, Result: []byte(`{"capabilities":{}}`)},
+		{Direction: directionToServer, Kind: "request", Method: "textDocument/hover", ID: &id2},
+		{Direction: directionFromServer, Kind: "notification", Method: "textDocument/publishDiagnostics"},
+		{Direction: directionFromServer, Kind: "response", ID: &id2, Result: []byte(`{"contents":"hi"}`)},
+	}
+
+	steps := buildReplaySteps(frames)
+	if len(steps) != 2 {
+		t.Fatalf("buildReplaySteps returned %d steps, want 2: %+v", len(steps), steps)
+	}
+
+	if steps[0].method != "initialize" || string(steps[0].result) != `{"capabilities":{}}` {
+		t.Errorf("steps[0] = %+v, want initialize with its recorded response", steps[0])
+	}
+	if steps[1].method != "textDocument/hover" || string(steps[1].result) != `{"contents":"hi"}` {
+		t.Errorf("steps[1] = %+v, want textDocument/hover with its recorded response", steps[1])
+	}
+	if len(steps[1].notifications) != 1 || steps[1].notifications[0].Method != "textDocument/publishDiagnostics" {
+		t.Errorf("steps[1].notifications = %+v, want the publishDiagnostics frame attached to it", steps[1].notifications)
+	}
+}
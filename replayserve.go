@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// replayStep is one client-to-server request from a recorded session,
+// paired with the response it got back and any server-to-client
+// notifications that arrived around it, in recorded order.
+type replayStep struct {
+	method        string
+	result        json.RawMessage
+	notifications []logFrame
+}
+
+// buildReplaySteps walks a recorded session's frames into an ordered list
+// of replaySteps, one per client-to-server request (including initialize),
+// so runReplayServe can answer a real client's requests the same way the
+// recorded server once did. Server-to-client notifications are attached to
+// whichever request most recently preceded them in the log.
+func buildReplaySteps(frames []logFrame) []replayStep {
+	var steps []replayStep
+	pendingByID := make(map[int]int) // request id -> index into steps
+	current := -1
+
+	for _, frame := range frames {
+		switch {
+		case frame.Direction == directionToServer && frame.Kind == "request":
+			steps = append(steps, replayStep{method: frame.Method})
+			current = len(steps) - 1
+			pendingByID[*frame.ID] = current
+
+		case frame.Direction == directionFromServer && frame.Kind == "response":
+			if idx, ok := pendingByID[*frame.ID]; ok {
+				steps[idx].result = frame.Result
+				delete(pendingByID, *frame.ID)
+			}
+
+		case frame.Direction == directionFromServer && frame.Kind == "notification":
+			if current >= 0 {
+				steps[current].notifications = append(steps[current].notifications, frame)
+			}
+		}
+	}
+
+	return steps
+}
+
+// runReplayServe makes lspit stand in as a fake language server: it reads
+// JSON-RPC frames from stdin the way a real server would and, for each
+// request a real client sends, writes back the next recorded response for
+// that method (plus the notifications recorded alongside it), instead of
+// running an actual server. This lets an editor be pointed at a recorded
+// session to debug client-side behavior offline, without the original
+// server build or workspace available.
+//
+// Matching is by method name, in recorded order: the first "textDocument/
+// hover" the client sends gets the first recorded hover response, the
+// second gets the second, and so on. That's a best-effort replay, not a
+// faithful re-simulation of the original server's internal state.
+func runReplayServe(logPath string) error {
+	_, frames, err := parseLogFile(logPath)
+	if err != nil {
+		return err
+	}
+
+	queues := make(map[string][]replayStep)
+	for _, step := range buildReplaySteps(frames) {
+		queues[step.method] = append(queues[step.method], step)
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	for {
+		content, err := readFramedMessage(in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading client frame: %w", err)
+		}
+
+		var msg struct {
+			ID     *int   `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal(content, &msg); err != nil {
+			continue
+		}
+
+		if msg.Method == "exit" {
+			return nil
+		}
+		if msg.ID == nil {
+			// A client notification (e.g. initialized, didOpen); the fake
+			// server has no recorded behavior to drive off of it.
+			continue
+		}
+
+		queue := queues[msg.Method]
+		if len(queue) == 0 {
+			if err := writeFramedMessage(os.Stdout, errorResponseJSON(*msg.ID, "no recorded response for "+msg.Method)); err != nil {
+				return fmt.Errorf("writing error response: %w", err)
+			}
+			continue
+		}
+		step := queue[0]
+		queues[msg.Method] = queue[1:]
+
+		if err := writeFramedMessage(os.Stdout, successResponseJSON(*msg.ID, step.result)); err != nil {
+			return fmt.Errorf("writing response: %w", err)
+		}
+		for _, notif := range step.notifications {
+			if err := writeFramedMessage(os.Stdout, rawFrameJSON(notif)); err != nil {
+				return fmt.Errorf("writing notification: %w", err)
+			}
+		}
+	}
+}
+
+// readFramedMessage reads one Content-Length-framed JSON-RPC message from
+// r, the same wire format LSPClient speaks to a real server.
+func readFramedMessage(r *bufio.Reader) ([]byte, error) {
+	contentLength := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "Content-Length:") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) == 2 {
+				contentLength, _ = strconv.Atoi(strings.TrimSpace(parts[1]))
+			}
+		}
+	}
+	if contentLength < 0 {
+		return nil, fmt.Errorf("frame missing Content-Length header")
+	}
+
+	content := make([]byte, contentLength)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// writeFramedMessage writes data to w with the Content-Length framing a
+// real LSP client expects.
+func writeFramedMessage(w io.Writer, data []byte) error {
+	_, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	return err
+}
+
+func successResponseJSON(id int, result json.RawMessage) []byte {
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"result":  result,
+	})
+	return data
+}
+
+func errorResponseJSON(id int, message string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error": map[string]interface{}{
+			"code":    -32603, // Internal error
+			"message": message,
+		},
+	})
+	return data
+}
@@ -1,11 +1,46 @@
 package main
 
 import (
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 )
 
+// findProjectRoot locates the workspace root for path, walking upward in
+// search of any of markers (e.g. "go.mod", "Cargo.toml", "package.json").
+// If no marker is found, it falls back to the git repository root.
+func findProjectRoot(path string, markers []string) (string, error) {
+	dir := path
+	if !isDir(dir) {
+		dir = filepath.Dir(path)
+	}
+
+	if root, ok := findUpward(dir, markers); ok {
+		return root, nil
+	}
+
+	return findGitRoot(path)
+}
+
+// findUpward walks up from dir looking for a directory containing any of
+// markers, returning the first one found.
+func findUpward(dir string, markers []string) (string, bool) {
+	for {
+		for _, marker := range markers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return dir, true
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
 // findGitRoot finds the git repository root containing the given path
 func findGitRoot(path string) (string, error) {
 	// Get directory if path is a file
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// textDocumentEdit mirrors the shape of a LSP TextDocumentEdit, one of the
+// variants a WorkspaceEdit's documentChanges entry can take (the others -
+// CreateFile/RenameFile/DeleteFile - are resource operations lspit doesn't
+// apply yet).
+type textDocumentEdit struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Edits []TextEdit `json:"edits"`
+}
+
+// ApplyWorkspaceEdit writes the file changes described by edit to disk, or
+// (when dryRun is true) prints a unified diff per file instead of writing
+// anything.
+func ApplyWorkspaceEdit(edit WorkspaceEdit, dryRun bool) error {
+	for uri, edits := range edit.Changes {
+		if err := applyEditsToFile(uri, edits, dryRun); err != nil {
+			return err
+		}
+	}
+
+	for _, raw := range edit.DocumentChanges {
+		var tde textDocumentEdit
+		if err := json.Unmarshal(raw, &tde); err != nil || tde.TextDocument.URI == "" || len(tde.Edits) == 0 {
+			fmt.Fprintln(os.Stderr, "Warning: skipping an unsupported documentChanges resource operation (create/rename/delete file)")
+			continue
+		}
+		if err := applyEditsToFile(tde.TextDocument.URI, tde.Edits, dryRun); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyEditsToFile(uri string, edits []TextEdit, dryRun bool) error {
+	path := strings.TrimPrefix(uri, "file://")
+
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	updated := applyTextEdits(string(original), edits)
+
+	if dryRun {
+		fmt.Print(unifiedDiff(path, string(original), updated))
+		return nil
+	}
+
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyTextEdits applies edits to content and returns the result. Edits
+// are applied back-to-front by position so earlier offsets stay valid.
+// Like the rest of lspit, positions are treated as byte offsets within a
+// line rather than UTF-16 code units.
+func applyTextEdits(content string, edits []TextEdit) string {
+	sorted := make([]TextEdit, len(edits))
+	copy(sorted, edits)
+	sort.Slice(sorted, func(i, j int) bool {
+		return comparePositions(sorted[i].Range.Start, sorted[j].Range.Start) > 0
+	})
+
+	for _, edit := range sorted {
+		start := byteOffset(content, edit.Range.Start)
+		end := byteOffset(content, edit.Range.End)
+		content = content[:start] + edit.NewText + content[end:]
+	}
+	return content
+}
+
+func byteOffset(content string, pos Position) int {
+	lines := strings.SplitAfter(content, "\n")
+	offset := 0
+	for i := 0; i < pos.Line && i < len(lines); i++ {
+		offset += len(lines[i])
+	}
+	if pos.Line >= len(lines) {
+		return offset
+	}
+	line := strings.TrimSuffix(lines[pos.Line], "\n")
+	if pos.Character > len(line) {
+		return offset + len(line)
+	}
+	return offset + pos.Character
+}
+
+func comparePositions(a, b Position) int {
+	if a.Line != b.Line {
+		return a.Line - b.Line
+	}
+	return a.Character - b.Character
+}